@@ -0,0 +1,36 @@
+// Package assetfs provides a filesystem abstraction over the asset sources
+// used by Diablo-related tools, so that callers may operate directly on an
+// extracted "diabdat.mpq" directory or on the raw, unextracted MPQ archive
+// without caring which one they were handed.
+package assetfs
+
+import (
+	"io"
+	"io/fs"
+)
+
+// FS is the interface implemented by asset sources that expose named files
+// for reading. Names are slash-separated paths relative to the root of the
+// asset source, e.g. "levels/l1data/l1.min".
+type FS interface {
+	// Open opens the named file for reading. The caller is responsible for
+	// closing the returned ReadCloser.
+	Open(name string) (io.ReadCloser, error)
+	// Stat returns file info for the named file.
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// Open opens the named asset from fsys. It is a convenience wrapper around
+// fsys.Open.
+func Open(fsys FS, name string) (io.ReadCloser, error) {
+	return fsys.Open(name)
+}
+
+// ListFS is implemented by asset sources that are able to enumerate the
+// files they contain, so that callers may discover assets (e.g. level
+// tilesets) rather than having to know their names in advance.
+type ListFS interface {
+	FS
+	// List returns the names of all files known to the asset source.
+	List() ([]string, error)
+}
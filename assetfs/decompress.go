@@ -0,0 +1,129 @@
+package assetfs
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/mewkiz/pkg/errutil"
+)
+
+// Per-sector compression method identifiers, stored as the first byte of a
+// compressed sector.
+const (
+	compressHuffman = 0x01
+	compressZlib    = 0x02
+	compressPKWare  = 0x08
+	compressBzip2   = 0x10
+)
+
+// defaultSectorSize is the uncompressed size of a sector when the archive's
+// block size is 0, i.e. 512 << 0.
+const defaultSectorSize = 512
+
+// maxNormalSize is a sanity limit on a file's claimed decompressed size,
+// guarding against a corrupt or malicious block table entry forcing a huge
+// allocation in decompressSectors before any sector has actually been read.
+const maxNormalSize = 256 << 20 // 256 MiB
+
+// readFile reads and, if required, decompresses the file described by
+// block.
+func (fsys *MPQFS) readFile(name string, block *blockEntry) ([]byte, error) {
+	if block.NormalSize > maxNormalSize {
+		return nil, errutil.Newf("assetfs: %q declares implausible decompressed size %d", name, block.NormalSize)
+	}
+	size, err := fsys.fileSize()
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if end := int64(block.FileOff) + int64(block.PackedSize); end > size {
+		return nil, errutil.Newf("assetfs: %q (offset %d, size %d) runs past end of archive (size %d)", name, block.FileOff, block.PackedSize, size)
+	}
+	raw := make([]byte, block.PackedSize)
+	if _, err := fsys.f.ReadAt(raw, int64(block.FileOff)); err != nil {
+		return nil, errutil.Err(err)
+	}
+	if block.Flags&blockEncrypted != 0 {
+		return nil, errutil.Newf("assetfs: encrypted file %q not yet supported", name)
+	}
+	if block.Flags&blockCompressed == 0 {
+		if block.NormalSize > block.PackedSize {
+			return nil, errutil.Newf("assetfs: %q claims decompressed size %d larger than its stored size %d", name, block.NormalSize, block.PackedSize)
+		}
+		return raw[:block.NormalSize], nil
+	}
+	sectorSize := defaultSectorSize << fsys.header.BlockSize
+	if block.Flags&blockSingleUnit != 0 {
+		return decompressSector(raw, int(block.NormalSize))
+	}
+	return decompressSectors(raw, int(block.NormalSize), sectorSize)
+}
+
+// decompressSectors splits raw into sector offset table plus sectors, and
+// decompresses each sector independently, as required for multi-sector MPQ
+// files.
+func decompressSectors(raw []byte, normalSize, sectorSize int) ([]byte, error) {
+	numSectors := (normalSize + sectorSize - 1) / sectorSize
+	offsets := make([]uint32, numSectors+1)
+	r := bytes.NewReader(raw)
+	if err := binary.Read(r, binary.LittleEndian, &offsets); err != nil {
+		return nil, errutil.Err(err)
+	}
+	out := make([]byte, 0, normalSize)
+	for i := 0; i < numSectors; i++ {
+		start, end := offsets[i], offsets[i+1]
+		if int(end) > len(raw) || start > end {
+			return nil, errutil.Newf("assetfs: invalid sector bounds [%d, %d)", start, end)
+		}
+		want := sectorSize
+		if rem := normalSize - len(out); rem < want {
+			want = rem
+		}
+		sector, err := decompressSector(raw[start:end], want)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		out = append(out, sector...)
+	}
+	return out, nil
+}
+
+// decompressSector decompresses a single sector, dispatching on the
+// compression method recorded in its first byte. If the sector is already as
+// large as its uncompressed size it is assumed to be stored verbatim.
+func decompressSector(sector []byte, wantSize int) ([]byte, error) {
+	if len(sector) == wantSize {
+		return sector, nil
+	}
+	if len(sector) == 0 {
+		return nil, nil
+	}
+	method, payload := sector[0], sector[1:]
+	switch method {
+	case compressZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		defer zr.Close()
+		data, err := ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return data, nil
+	case compressBzip2:
+		data, err := ioutil.ReadAll(bzip2.NewReader(bytes.NewReader(payload)))
+		if err != nil {
+			return nil, errutil.Err(err)
+		}
+		return data, nil
+	case compressPKWare:
+		return nil, errutil.Newf("assetfs: PKWARE implode decompression not yet supported")
+	case compressHuffman:
+		return nil, errutil.Newf("assetfs: Huffman (WAVE) decompression not yet supported")
+	default:
+		return nil, errutil.Newf("assetfs: unsupported sector compression method 0x%02X", method)
+	}
+}
@@ -0,0 +1,65 @@
+package assetfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mewkiz/pkg/errutil"
+)
+
+// DirFS implements FS by reading files from an extracted "diabdat.mpq"
+// directory on the local filesystem.
+type DirFS struct {
+	// root is the path of the extracted MPQ directory.
+	root string
+}
+
+// NewDirFS returns an FS which reads assets from the extracted MPQ directory
+// rooted at root.
+func NewDirFS(root string) *DirFS {
+	return &DirFS{root: root}
+}
+
+// Open opens the named file for reading.
+func (fsys *DirFS) Open(name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(fsys.root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return f, nil
+}
+
+// Stat returns file info for the named file.
+func (fsys *DirFS) Stat(name string) (fs.FileInfo, error) {
+	fi, err := os.Stat(filepath.Join(fsys.root, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return fi, nil
+}
+
+// List returns the slash-separated, root-relative names of all files found
+// by walking the extracted MPQ directory.
+func (fsys *DirFS) List() ([]string, error) {
+	var names []string
+	err := filepath.Walk(fsys.root, func(p string, fi fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(fsys.root, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return names, nil
+}
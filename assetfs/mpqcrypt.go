@@ -0,0 +1,73 @@
+package assetfs
+
+// Hash types used to index into the MPQ encryption table, as defined by the
+// MPQ format.
+const (
+	hashTableOffset = 0
+	hashNameA       = 1
+	hashNameB       = 2
+	hashTable       = 3
+	hashFileKey     = 4
+)
+
+// cryptTable is the standard MPQ encryption/decryption table, derived from a
+// fixed pseudo-random sequence seeded with 0x00100001. It is used both to
+// hash file names and to encrypt and decrypt the hash table, block table and
+// file contents of an MPQ archive.
+var cryptTable = genCryptTable()
+
+// genCryptTable generates the 0x500-entry MPQ encryption table.
+func genCryptTable() [0x500]uint32 {
+	var table [0x500]uint32
+	seed := uint32(0x00100001)
+	for index1 := 0; index1 < 0x100; index1++ {
+		index2 := index1
+		for i := 0; i < 5; i++ {
+			seed = (seed*125 + 3) % 0x2AAAAB
+			temp1 := (seed & 0xFFFF) << 16
+			seed = (seed*125 + 3) % 0x2AAAAB
+			temp2 := seed & 0xFFFF
+			table[index2] = temp1 | temp2
+			index2 += 0x100
+		}
+	}
+	return table
+}
+
+// hashString hashes name using the MPQ hashing algorithm for the given hash
+// type (one of the hash* constants above).
+func hashString(name string, hashType uint32) uint32 {
+	seed1 := uint32(0x7FED7FED)
+	seed2 := uint32(0xEEEEEEEE)
+	for i := 0; i < len(name); i++ {
+		ch := uint32(toUpperASCII(name[i]))
+		seed1 = cryptTable[hashType*0x100+ch] ^ (seed1 + seed2)
+		seed2 = ch + seed1 + seed2 + (seed2 << 5) + 3
+	}
+	return seed1
+}
+
+// toUpperASCII returns the upper-case variant of the given ASCII byte,
+// converting '/' to '\\' as required when hashing MPQ file paths.
+func toUpperASCII(b byte) byte {
+	if b == '/' {
+		return '\\'
+	}
+	if 'a' <= b && b <= 'z' {
+		return b - 'a' + 'A'
+	}
+	return b
+}
+
+// decryptBlock decrypts data in-place using the given key, as used for the
+// MPQ hash table, block table and encrypted file contents.
+func decryptBlock(data []uint32, key uint32) {
+	seed2 := uint32(0xEEEEEEEE)
+	for i := range data {
+		seed2 += cryptTable[hashFileKey*0x100+(key&0xFF)]
+		value := data[i] ^ (key + seed2)
+		key = ((^key << 0x15) + 0x11111111) | (key >> 0x0B)
+		seed2 = value + seed2 + (seed2 << 5) + 3
+		data[i] = value
+	}
+}
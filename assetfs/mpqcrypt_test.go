@@ -0,0 +1,63 @@
+package assetfs
+
+import "testing"
+
+func TestHashStringDeterministic(t *testing.T) {
+	const name = "levels\\l1data\\l1.min"
+	got1 := hashString(name, hashNameA)
+	got2 := hashString(name, hashNameA)
+	if got1 != got2 {
+		t.Fatalf("hashString not deterministic: %#x != %#x", got1, got2)
+	}
+	if got1 == hashString(name, hashNameB) {
+		t.Fatalf("hashString produced the same value for NAME_A and NAME_B hash types")
+	}
+}
+
+func TestHashStringNormalizesSlashAndCase(t *testing.T) {
+	a := hashString("levels/l1data/l1.min", hashNameA)
+	b := hashString(`LEVELS\L1DATA\L1.MIN`, hashNameA)
+	if a != b {
+		t.Errorf("hashString should be case-insensitive and treat '/' as '\\\\'; got %#x and %#x", a, b)
+	}
+}
+
+func TestDecryptBlockRoundTrip(t *testing.T) {
+	orig := []uint32{0x11111111, 0x22222222, 0x33333333, 0x44444444}
+	data := append([]uint32(nil), orig...)
+	key := hashString("(hash table)", hashFileKey)
+
+	encryptBlock(data, key)
+	if equalUint32(data, orig) {
+		t.Fatal("encryptBlock left data unchanged")
+	}
+	decryptBlock(data, key)
+	if !equalUint32(data, orig) {
+		t.Errorf("decrypt(encrypt(x)) = %#v, want %#v", data, orig)
+	}
+}
+
+// encryptBlock is the mirror of decryptBlock, used only by tests to build
+// synthetic encrypted tables without needing a real MPQ archive as a fixture.
+func encryptBlock(data []uint32, key uint32) {
+	seed2 := uint32(0xEEEEEEEE)
+	for i := range data {
+		seed2 += cryptTable[hashFileKey*0x100+(key&0xFF)]
+		plain := data[i]
+		data[i] = plain ^ (key + seed2)
+		key = ((^key << 0x15) + 0x11111111) | (key >> 0x0B)
+		seed2 = plain + seed2 + (seed2 << 5) + 3
+	}
+}
+
+func equalUint32(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,289 @@
+package assetfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mewkiz/pkg/errutil"
+)
+
+// mpqMagic is the magic number found at the start of an MPQ archive.
+var mpqMagic = [4]byte{'M', 'P', 'Q', 0x1A}
+
+// mpqHeader is the layout of the MPQ archive header.
+type mpqHeader struct {
+	Magic         [4]byte
+	HeaderSize    uint32
+	ArchiveSize   uint32
+	FormatVersion uint16
+	BlockSize     uint16
+	HashTableOff  uint32
+	BlockTableOff uint32
+	HashTableSize uint32
+	BlockTabSize  uint32
+}
+
+// hashEntry is an entry of the MPQ hash table, mapping a (hashed) file name
+// to an entry of the block table.
+type hashEntry struct {
+	NameA    uint32
+	NameB    uint32
+	Locale   uint16
+	Platform uint16
+	BlockIdx uint32
+}
+
+// blockEntry is an entry of the MPQ block table, describing the location and
+// encoding of a single file within the archive.
+type blockEntry struct {
+	FileOff    uint32
+	PackedSize uint32
+	NormalSize uint32
+	Flags      uint32
+}
+
+// Block table flags.
+const (
+	blockFileExists = 0x80000000
+	blockCompressed = 0x00000200 // PKWARE or multiple compression, sector-based
+	blockEncrypted  = 0x00010000
+	blockSingleUnit = 0x01000000
+)
+
+// MPQFS implements FS by reading entries directly out of an unextracted
+// "diabdat.mpq" archive, parsing its hash and block tables on open and
+// decompressing requested files on demand.
+type MPQFS struct {
+	f          *os.File
+	header     mpqHeader
+	hashTable  []hashEntry
+	blockTable []blockEntry
+	modTime    time.Time
+}
+
+// OpenMPQ opens the MPQ archive at path and parses its hash and block
+// tables.
+func OpenMPQ(path string) (*MPQFS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	fsys := &MPQFS{f: f}
+	if fi, err := f.Stat(); err == nil {
+		fsys.modTime = fi.ModTime()
+	}
+	if err := fsys.parseHeader(); err != nil {
+		f.Close()
+		return nil, errutil.Err(err)
+	}
+	if err := fsys.parseHashTable(); err != nil {
+		f.Close()
+		return nil, errutil.Err(err)
+	}
+	if err := fsys.parseBlockTable(); err != nil {
+		f.Close()
+		return nil, errutil.Err(err)
+	}
+	return fsys, nil
+}
+
+// Close closes the underlying MPQ archive.
+func (fsys *MPQFS) Close() error {
+	return fsys.f.Close()
+}
+
+// parseHeader locates and decodes the MPQ header.
+func (fsys *MPQFS) parseHeader() error {
+	var magic [4]byte
+	if _, err := fsys.f.ReadAt(magic[:], 0); err != nil {
+		return errutil.Err(err)
+	}
+	if magic != mpqMagic {
+		return errutil.Newf("assetfs: invalid MPQ magic number %q", magic)
+	}
+	if _, err := fsys.f.Seek(0, io.SeekStart); err != nil {
+		return errutil.Err(err)
+	}
+	if err := binary.Read(fsys.f, binary.LittleEndian, &fsys.header); err != nil {
+		return errutil.Err(err)
+	}
+	return nil
+}
+
+// parseHashTable reads and decrypts the MPQ hash table.
+func (fsys *MPQFS) parseHashTable() error {
+	n := int(fsys.header.HashTableSize)
+	if err := fsys.checkTableBounds(n, fsys.header.HashTableOff); err != nil {
+		return errutil.Err(err)
+	}
+	raw := make([]uint32, n*4)
+	if err := fsys.readTable(int64(fsys.header.HashTableOff), raw); err != nil {
+		return errutil.Err(err)
+	}
+	decryptBlock(raw, hashString("(hash table)", hashFileKey))
+	fsys.hashTable = make([]hashEntry, n)
+	for i := 0; i < n; i++ {
+		e := &fsys.hashTable[i]
+		e.NameA = raw[i*4+0]
+		e.NameB = raw[i*4+1]
+		e.Locale = uint16(raw[i*4+2])
+		e.Platform = uint16(raw[i*4+2] >> 16)
+		e.BlockIdx = raw[i*4+3]
+	}
+	return nil
+}
+
+// parseBlockTable reads and decrypts the MPQ block table.
+func (fsys *MPQFS) parseBlockTable() error {
+	n := int(fsys.header.BlockTabSize)
+	if err := fsys.checkTableBounds(n, fsys.header.BlockTableOff); err != nil {
+		return errutil.Err(err)
+	}
+	raw := make([]uint32, n*4)
+	if err := fsys.readTable(int64(fsys.header.BlockTableOff), raw); err != nil {
+		return errutil.Err(err)
+	}
+	decryptBlock(raw, hashString("(block table)", hashFileKey))
+	fsys.blockTable = make([]blockEntry, n)
+	for i := 0; i < n; i++ {
+		e := &fsys.blockTable[i]
+		e.FileOff = raw[i*4+0]
+		e.PackedSize = raw[i*4+1]
+		e.NormalSize = raw[i*4+2]
+		e.Flags = raw[i*4+3]
+	}
+	return nil
+}
+
+// fileSize returns the size in bytes of the underlying archive file.
+func (fsys *MPQFS) fileSize() (int64, error) {
+	fi, err := fsys.f.Stat()
+	if err != nil {
+		return 0, errutil.Err(err)
+	}
+	return fi.Size(), nil
+}
+
+// checkTableBounds reports an error if a table of n 16-byte entries starting
+// at off would run past the end of the archive, guarding against absurd
+// allocations when reading a corrupt or truncated MPQ header.
+func (fsys *MPQFS) checkTableBounds(n int, off uint32) error {
+	if n < 0 {
+		return errutil.Newf("assetfs: invalid table entry count %d", n)
+	}
+	size, err := fsys.fileSize()
+	if err != nil {
+		return errutil.Err(err)
+	}
+	const entrySize = 16 // 4 little-endian uint32 fields per entry
+	end := int64(off) + int64(n)*entrySize
+	if end > size {
+		return errutil.Newf("assetfs: table of %d entries at offset %d runs past end of archive (size %d)", n, off, size)
+	}
+	return nil
+}
+
+// readTable reads len(dst) little-endian uint32 values starting at off into
+// dst.
+func (fsys *MPQFS) readTable(off int64, dst []uint32) error {
+	buf := make([]byte, len(dst)*4)
+	if _, err := fsys.f.ReadAt(buf, off); err != nil {
+		return errutil.Err(err)
+	}
+	for i := range dst {
+		dst[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+	return nil
+}
+
+// findBlock locates the block table entry for the named file.
+func (fsys *MPQFS) findBlock(name string) (*blockEntry, error) {
+	mask := uint32(len(fsys.hashTable) - 1)
+	index := hashString(name, hashTableOffset) & mask
+	nameA := hashString(name, hashNameA)
+	nameB := hashString(name, hashNameB)
+	for i := uint32(0); i < uint32(len(fsys.hashTable)); i++ {
+		e := &fsys.hashTable[(index+i)%uint32(len(fsys.hashTable))]
+		if e.NameA == 0xFFFFFFFF && e.NameB == 0xFFFFFFFF {
+			break
+		}
+		if e.NameA == nameA && e.NameB == nameB {
+			if e.BlockIdx >= uint32(len(fsys.blockTable)) {
+				return nil, errutil.Newf("assetfs: block index out of range for %q", name)
+			}
+			return &fsys.blockTable[e.BlockIdx], nil
+		}
+	}
+	return nil, errutil.Newf("assetfs: %q not found in MPQ archive", name)
+}
+
+// Open opens the named file for reading, decompressing it if required.
+func (fsys *MPQFS) Open(name string) (io.ReadCloser, error) {
+	block, err := fsys.findBlock(name)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	if block.Flags&blockFileExists == 0 {
+		return nil, errutil.Newf("assetfs: %q does not exist in MPQ archive", name)
+	}
+	data, err := fsys.readFile(name, block)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// List returns the names of all files recorded in the archive's internal
+// "(listfile)", the de facto standard way of enumerating the contents of an
+// MPQ archive (its hash table stores only hashed names, never the names
+// themselves). It returns an error if the archive has no listfile.
+func (fsys *MPQFS) List() ([]string, error) {
+	r, err := fsys.Open("(listfile)")
+	if err != nil {
+		return nil, errutil.Newf("assetfs: archive has no \"(listfile)\" to enumerate contents: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var names []string
+	for _, line := range bytes.Split(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		names = append(names, strings.ReplaceAll(string(line), `\`, "/"))
+	}
+	return names, nil
+}
+
+// Stat returns file info for the named file. Since MPQ archives do not
+// record modification times per file, the archive's own modification time
+// is reported.
+func (fsys *MPQFS) Stat(name string) (fs.FileInfo, error) {
+	block, err := fsys.findBlock(name)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return &mpqFileInfo{name: name, size: int64(block.NormalSize), modTime: fsys.modTime}, nil
+}
+
+// mpqFileInfo implements fs.FileInfo for a file stored in an MPQ archive.
+type mpqFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *mpqFileInfo) Name() string       { return fi.name }
+func (fi *mpqFileInfo) Size() int64        { return fi.size }
+func (fi *mpqFileInfo) Mode() fs.FileMode  { return 0444 }
+func (fi *mpqFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *mpqFileInfo) IsDir() bool        { return false }
+func (fi *mpqFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,148 @@
+package assetfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildMPQ assembles a minimal, valid MPQ v1 archive in memory containing a
+// single stored (uncompressed) file, so that MPQFS can be exercised without
+// a real "diabdat.mpq" fixture.
+func buildMPQ(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	return buildMPQBlock(t, name, content, uint32(len(content)), uint32(len(content)), blockFileExists)
+}
+
+// buildMPQBlock is like buildMPQ, but lets the test control the block table
+// entry's declared packedSize, normalSize and flags independently of the
+// actual bytes stored for content, so that corrupt/lying entries can be
+// exercised.
+func buildMPQBlock(t *testing.T, name string, content []byte, packedSize, normalSize, flags uint32) []byte {
+	t.Helper()
+
+	const hashTableSize = 4 // must be a power of two
+	const headerSize = 32
+	fileOff := uint32(headerSize)
+	hashTableOff := fileOff + uint32(len(content))
+	blockTableOff := hashTableOff + hashTableSize*16
+	archiveSize := blockTableOff + 1*16
+
+	header := mpqHeader{
+		Magic:         mpqMagic,
+		HeaderSize:    headerSize,
+		ArchiveSize:   archiveSize,
+		FormatVersion: 0,
+		BlockSize:     3,
+		HashTableOff:  hashTableOff,
+		BlockTableOff: blockTableOff,
+		HashTableSize: hashTableSize,
+		BlockTabSize:  1,
+	}
+
+	// Build the hash table: one occupied slot for name, the rest marked
+	// empty (0xFFFFFFFF/0xFFFFFFFF), as real MPQ archives do.
+	hashRaw := make([]uint32, hashTableSize*4)
+	for i := 0; i < hashTableSize; i++ {
+		hashRaw[i*4+0] = 0xFFFFFFFF
+		hashRaw[i*4+1] = 0xFFFFFFFF
+	}
+	index := hashString(name, hashTableOffset) % hashTableSize
+	hashRaw[index*4+0] = hashString(name, hashNameA)
+	hashRaw[index*4+1] = hashString(name, hashNameB)
+	hashRaw[index*4+2] = 0
+	hashRaw[index*4+3] = 0 // block index 0
+	encryptBlock(hashRaw, hashString("(hash table)", hashFileKey))
+
+	// Build the block table: a single file entry, with caller-controlled
+	// sizes and flags.
+	blockRaw := []uint32{fileOff, packedSize, normalSize, flags}
+	encryptBlock(blockRaw, hashString("(block table)", hashFileKey))
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, &header); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	buf.Write(content)
+	if err := binary.Write(buf, binary.LittleEndian, hashRaw); err != nil {
+		t.Fatalf("write hash table: %v", err)
+	}
+	if err := binary.Write(buf, binary.LittleEndian, blockRaw); err != nil {
+		t.Fatalf("write block table: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func openTestMPQ(t *testing.T, data []byte) *MPQFS {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.mpq")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write test archive: %v", err)
+	}
+	fsys, err := OpenMPQ(path)
+	if err != nil {
+		t.Fatalf("OpenMPQ: %v", err)
+	}
+	t.Cleanup(func() { fsys.Close() })
+	return fsys
+}
+
+func TestMPQFSOpenStoredFile(t *testing.T) {
+	const name = "levels\\l1data\\l1.min"
+	want := []byte("hello, sanctuary")
+	fsys := openTestMPQ(t, buildMPQ(t, name, want))
+
+	fi, err := fsys.Stat(name)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Size() != int64(len(want)) {
+		t.Errorf("Stat size = %d, want %d", fi.Size(), len(want))
+	}
+
+	r, err := fsys.Open(name)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	got := new(bytes.Buffer)
+	if _, err := got.ReadFrom(r); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("Open contents = %q, want %q", got.Bytes(), want)
+	}
+}
+
+func TestMPQFSOpenMissingFile(t *testing.T) {
+	fsys := openTestMPQ(t, buildMPQ(t, "foo.txt", []byte("data")))
+	if _, err := fsys.Open("does-not-exist.txt"); err == nil {
+		t.Fatal("Open: expected error for missing file, got nil")
+	}
+}
+
+func TestMPQFSRejectsNormalSizeLargerThanPackedSize(t *testing.T) {
+	// An uncompressed block entry that lies about its decompressed size
+	// being larger than what was actually stored must be rejected, not
+	// slice out of bounds when serving it back.
+	data := buildMPQBlock(t, "foo.txt", []byte("data"), 4, 100, blockFileExists)
+	fsys := openTestMPQ(t, data)
+	if _, err := fsys.Open("foo.txt"); err == nil {
+		t.Fatal("Open: expected error for NormalSize > PackedSize, got nil")
+	}
+}
+
+func TestMPQFSRejectsTruncatedTables(t *testing.T) {
+	data := buildMPQ(t, "foo.txt", []byte("data"))
+	// Truncate the archive so the block table, still referenced by the
+	// header, runs past the end of the file.
+	path := filepath.Join(t.TempDir(), "truncated.mpq")
+	if err := os.WriteFile(path, data[:len(data)-8], 0644); err != nil {
+		t.Fatalf("write truncated archive: %v", err)
+	}
+	if _, err := OpenMPQ(path); err == nil {
+		t.Fatal("OpenMPQ: expected error for truncated block table, got nil")
+	}
+}
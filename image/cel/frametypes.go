@@ -0,0 +1,31 @@
+package cel
+
+import (
+	"io"
+
+	"github.com/mewkiz/pkg/errutil"
+	"github.com/sanctuary/formats/image/cel/internal/frametypes"
+)
+
+// FrameTypes maps from level name (e.g. "l1", "town") to the mapping from
+// frame number to frame type of that level's CEL file. It is populated
+// either by the generated "data.go" (the "go" output format of gen.go) or,
+// in "embed_frametypes" builds, by LoadFrameTypes from an embedded
+// "frametypes.bin" (see "frametypes_embed.go") -- either way without
+// requiring the other.
+var FrameTypes map[string][]int
+
+// LoadFrameTypes reads a level name to frame-type mapping from r, as written
+// by gen.go in "-format=binary" mode. It is an alternative to the
+// generated "data.go", intended for consumers that would rather embed the
+// mapping as a resource than recompile on every asset regeneration. The
+// returned mapping is keyed the same way as the generated FrameTypes
+// variable, and callers that embed it are expected to assign it to
+// FrameTypes themselves (see "frametypes_embed.go").
+func LoadFrameTypes(r io.Reader) (map[string][]int, error) {
+	m, err := frametypes.Decode(r)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return m, nil
+}
@@ -0,0 +1,26 @@
+//go:build embed_frametypes
+
+package cel
+
+import (
+	"bytes"
+	_ "embed"
+	"log"
+)
+
+// frametypes.bin is produced by running gen.go with "-format=binary"; it is
+// not checked into the repository by default. Consumers that wish to embed
+// the frame-type mapping as a resource rather than linking against the
+// generated "data.go" may place frametypes.bin next to this file and build
+// with the "embed_frametypes" build tag.
+//
+//go:embed frametypes.bin
+var frameTypesBin []byte
+
+func init() {
+	m, err := LoadFrameTypes(bytes.NewReader(frameTypesBin))
+	if err != nil {
+		log.Fatalf("unable to load embedded frame types: %v", err)
+	}
+	FrameTypes = m
+}
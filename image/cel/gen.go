@@ -1,4 +1,5 @@
-//+build ignore
+//go:build ignore
+// +build ignore
 
 // gen.go generates the data files required to decode CEL images, which specify
 // the decoding algorithms, image dimensions, palettes and colour transitions of
@@ -12,83 +13,83 @@ import (
 	"io/ioutil"
 	"log"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/mewkiz/pkg/errutil"
-	"github.com/sanctuary/formats/level/min"
+	"github.com/sanctuary/formats/assetfs"
+	"github.com/sanctuary/formats/image/cel/internal/frametypes"
+	"github.com/sanctuary/formats/level/tileset"
 )
 
 func main() {
 	var (
-		// mpqDir specifies the path to an extracted "diabdat.mpq".
-		mpqDir string
+		// mpqPath specifies the path to either an extracted "diabdat.mpq"
+		// directory or the raw, unextracted "diabdat.mpq" archive.
+		mpqPath string
+		// format specifies the output format of the frame-type mapping;
+		// either "go" (the default, generating "data.go") or "binary"
+		// (generating "frametypes.bin", a compact sidecar file).
+		format string
 	)
-	flag.StringVar(&mpqDir, "mpqdir", "diabdat/", `Path to extracted "diabdat.mpq".`)
+	flag.StringVar(&mpqPath, "mpqdir", "diabdat/", `Path to extracted "diabdat.mpq", or to the raw .mpq archive.`)
+	flag.StringVar(&format, "format", "go", `Output format of the frame-type mapping ("go" or "binary").`)
 	flag.Parse()
 
-	// Parse MIN files.
-	levelNames := []string{"l1", "l2", "l3", "l4", "town"}
-	var mappings []*minMapping
-	for _, levelName := range levelNames {
-		mapping, err := parseMin(mpqDir, levelName)
-		if err != nil {
-			log.Fatal(err)
-		}
-		mappings = append(mappings, mapping)
+	fsys, err := openAssetFS(mpqPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Generate "data.go".
-	if err := genData(mappings); err != nil {
+	// Discover and parse MIN files; this picks up every level tileset found
+	// in fsys, including ones beyond the base game's "l1".."l4" and "town"
+	// (e.g. Hellfire's "l5", "l6", "nest" and "crypt"), without requiring
+	// gen.go to know their names ahead of time.
+	levels, err := tileset.Discover(fsys)
+	if err != nil {
 		log.Fatal(err)
 	}
-}
 
-// A minMapping specifies the mapping between frame numbers and frame types of a
-// given MIN file.
-type minMapping struct {
-	// Level name.
-	LevelName string
-	// frameTypes maps from frame number to frame type.
-	FrameTypes []int
+	switch format {
+	case "go":
+		// Generate "data.go".
+		if err := genData(levels.All()); err != nil {
+			log.Fatal(err)
+		}
+	case "binary":
+		// Generate "frametypes.bin".
+		if err := genBinary(levels.All()); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf(`invalid output format %q; expected "go" or "binary"`, format)
+	}
 }
 
-// parseMin parses the given MIN file and returns a mapping from frame numbers
-// to frame types.
-func parseMin(mpqDir, levelName string) (*minMapping, error) {
-	// MIN path; e.g. "diabdat/levels/l1data/l1.cel".
-	name := levelName + ".min"
-	minPath := filepath.Join(mpqDir, "levels", levelName+"data", name)
-	pieces, err := min.Parse(minPath)
-	if err != nil {
-		return nil, errutil.Err(err)
-	}
-	// m maps from frame numbers to frame types.
-	m := make(map[int]int)
-	for _, piece := range pieces {
-		for _, block := range piece.Blocks {
-			m[block.FrameNum] = block.FrameType
+// openAssetFS returns an assetfs.FS rooted at mpqPath, dispatching to MPQFS
+// or DirFS depending on whether mpqPath refers to a raw MPQ archive or an
+// already extracted directory.
+func openAssetFS(mpqPath string) (assetfs.FS, error) {
+	if strings.EqualFold(filepath.Ext(mpqPath), ".mpq") {
+		fsys, err := assetfs.OpenMPQ(mpqPath)
+		if err != nil {
+			return nil, errutil.Err(err)
 		}
+		return fsys, nil
 	}
-	mapping := &minMapping{
-		LevelName:  levelName,
-		FrameTypes: make([]int, len(m)),
-	}
-	for frameNum, frameType := range m {
-		mapping.FrameTypes[frameNum] = frameType
-	}
-	return mapping, nil
+	return assetfs.NewDirFS(mpqPath), nil
 }
 
 // genData generates the data files required to decode CEL images, which specify
 // the decoding algorithms, image dimensions, palettes and colour transitions of
 // each CEL image.
-func genData(mappings []*minMapping) error {
+func genData(frameTypes map[string][]int) error {
 	t := template.New("data")
 	if _, err := t.Parse(dataContent[1:]); err != nil {
 		return errutil.Err(err)
 	}
 	buf := new(bytes.Buffer)
-	if err := t.Execute(buf, mappings); err != nil {
+	if err := t.Execute(buf, frameTypes); err != nil {
 		return errutil.Err(err)
 	}
 	data, err := format.Source(buf.Bytes())
@@ -101,6 +102,21 @@ func genData(mappings []*minMapping) error {
 	return nil
 }
 
+// genBinary writes the frame-type mappings to "frametypes.bin", a compact
+// binary sidecar file that may be loaded at run-time through
+// cel.LoadFrameTypes, or shipped as an embedded resource (see
+// "frametypes_embed.go").
+func genBinary(frameTypes map[string][]int) error {
+	buf := new(bytes.Buffer)
+	if err := frametypes.Encode(buf, frameTypes); err != nil {
+		return errutil.Err(err)
+	}
+	if err := ioutil.WriteFile("frametypes.bin", buf.Bytes(), 0644); err != nil {
+		return errutil.Err(err)
+	}
+	return nil
+}
+
 const dataContent = `
 // generated by gen.go using 'go generate'; DO NOT EDIT.
 
@@ -122,11 +138,14 @@ var decoders = [...]func([]byte, int, int, color.Palette) image.Image{
 	6: decodeType6,
 }
 
-// Mappings from frame numbers to frame types for each of the level CEL files
-// "l1.cel", "l2.cel", "l3.cel", "l4.cel" and "town.cel".
-var (
-{{- range . }}
-	{{ .LevelName }}FrameTypes = {{ printf "%#v" .FrameTypes }}
+// init populates FrameTypes (declared in "frametypes.go") with the mapping
+// baked in at generation time, so that "go" format builds work without
+// requiring "frametypes.bin" to also be present.
+func init() {
+	FrameTypes = map[string][]int{
+{{- range $levelName, $frameTypes := . }}
+		{{ printf "%q" $levelName }}: {{ printf "%#v" $frameTypes }},
 {{- end }}
-)
+	}
+}
 `
@@ -0,0 +1,131 @@
+// Package frametypes implements a compact binary encoding of the mapping
+// from CEL frame numbers to frame types of each level, as an alternative to
+// baking the mapping into generated Go source.
+//
+// Layout:
+//
+//	magic      [4]byte  "CFT1"
+//	version    uint8
+//	numLevels  uint8
+//	levels     []level
+//
+// Each level is encoded as:
+//
+//	nameLen    uint8
+//	name       [nameLen]byte
+//	numFrames  uint32 (little-endian)
+//	frameTypes [numFrames]uint8 // frame types are in the range 0..6
+package frametypes
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/mewkiz/pkg/errutil"
+)
+
+// magic identifies the file format of a frame-type mapping file.
+var magic = [4]byte{'C', 'F', 'T', '1'}
+
+// version is the current format version.
+const version = 1
+
+// maxFrameCount is a sanity limit on the number of frames a single level may
+// declare, guarding against allocating huge slices when decoding a corrupt
+// or malicious frametypes.bin.
+const maxFrameCount = 1 << 20
+
+// Encode writes the given level name to frame-type mapping to w in the
+// compact binary layout described in the package doc comment.
+func Encode(w io.Writer, mappings map[string][]int) error {
+	if len(mappings) > 0xFF {
+		return errutil.Newf("frametypes: too many levels (%d) to encode", len(mappings))
+	}
+	if _, err := w.Write(magic[:]); err != nil {
+		return errutil.Err(err)
+	}
+	if _, err := w.Write([]byte{version, byte(len(mappings))}); err != nil {
+		return errutil.Err(err)
+	}
+	// Sort level names for deterministic output.
+	names := make([]string, 0, len(mappings))
+	for name := range mappings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		frameTypes := mappings[name]
+		if len(name) > 0xFF {
+			return errutil.Newf("frametypes: level name %q too long to encode", name)
+		}
+		if _, err := w.Write([]byte{byte(len(name))}); err != nil {
+			return errutil.Err(err)
+		}
+		if _, err := io.WriteString(w, name); err != nil {
+			return errutil.Err(err)
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(frameTypes))); err != nil {
+			return errutil.Err(err)
+		}
+		packed := make([]byte, len(frameTypes))
+		for i, frameType := range frameTypes {
+			if frameType < 0 || frameType > 0xFF {
+				return errutil.Newf("frametypes: frame type %d of level %q out of range", frameType, name)
+			}
+			packed[i] = byte(frameType)
+		}
+		if _, err := w.Write(packed); err != nil {
+			return errutil.Err(err)
+		}
+	}
+	return nil
+}
+
+// Decode reads a level name to frame-type mapping from r, as produced by
+// Encode.
+func Decode(r io.Reader) (map[string][]int, error) {
+	var gotMagic [4]byte
+	if _, err := io.ReadFull(r, gotMagic[:]); err != nil {
+		return nil, errutil.Err(err)
+	}
+	if gotMagic != magic {
+		return nil, errutil.Newf("frametypes: invalid magic number %q", gotMagic)
+	}
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, errutil.Err(err)
+	}
+	gotVersion, numLevels := head[0], head[1]
+	if gotVersion != version {
+		return nil, errutil.Newf("frametypes: unsupported format version %d", gotVersion)
+	}
+	mappings := make(map[string][]int, numLevels)
+	for i := 0; i < int(numLevels); i++ {
+		var nameLen [1]byte
+		if _, err := io.ReadFull(r, nameLen[:]); err != nil {
+			return nil, errutil.Err(err)
+		}
+		nameBuf := make([]byte, nameLen[0])
+		if _, err := io.ReadFull(r, nameBuf); err != nil {
+			return nil, errutil.Err(err)
+		}
+		var numFrames uint32
+		if err := binary.Read(r, binary.LittleEndian, &numFrames); err != nil {
+			return nil, errutil.Err(err)
+		}
+		if numFrames > maxFrameCount {
+			return nil, errutil.Newf("frametypes: frame count %d of level %q exceeds sane maximum", numFrames, nameBuf)
+		}
+		packed := make([]byte, numFrames)
+		if _, err := io.ReadFull(r, packed); err != nil {
+			return nil, errutil.Err(err)
+		}
+		frameTypes := make([]int, numFrames)
+		for j, frameType := range packed {
+			frameTypes[j] = int(frameType)
+		}
+		mappings[string(nameBuf)] = frameTypes
+	}
+	return mappings, nil
+}
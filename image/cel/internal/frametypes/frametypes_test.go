@@ -0,0 +1,48 @@
+package frametypes
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	want := map[string][]int{
+		"l1":   {0, 1, 2, 3, 4, 5, 6},
+		"town": {0},
+		"l4":   {},
+	}
+	buf := new(bytes.Buffer)
+	if err := Encode(buf, want); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round-trip mismatch; got %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeInvalidMagic(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte("not a frametypes file")))
+	if err == nil {
+		t.Fatal("Decode: expected error for invalid magic number, got nil")
+	}
+}
+
+func TestDecodeRejectsImplausibleFrameCount(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	buf.WriteByte(version)
+	buf.WriteByte(1) // one level
+	buf.WriteByte(1) // name length
+	buf.WriteString("x")
+	// A frame count far beyond any real level, with no frame data to back
+	// it; Decode must reject this before allocating.
+	buf.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	if _, err := Decode(&buf); err == nil {
+		t.Fatal("Decode: expected error for implausible frame count, got nil")
+	}
+}
@@ -0,0 +1,89 @@
+// Package min provides access to the MIN file format, which describes how
+// level tile pieces are composed from a grid of CEL sub-tile blocks.
+package min
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/mewkiz/pkg/errutil"
+	"github.com/sanctuary/formats/assetfs"
+)
+
+// Number of blocks per tile piece. Town uses 10-block pieces; every other
+// level tileset uses 16-block pieces.
+const (
+	blocksPerPieceTown    = 10
+	blocksPerPieceDungeon = 16
+)
+
+// A Piece is a tile piece composed of a fixed-size grid of blocks.
+type Piece struct {
+	// Blocks of the piece, in left-to-right, top-to-bottom order.
+	Blocks []Block
+}
+
+// A Block specifies the CEL frame and decoding algorithm ("frame type") of a
+// single cell within a tile piece.
+type Block struct {
+	// FrameNum is the CEL frame number of the block; a frame number of 0
+	// indicates an empty (transparent) block.
+	FrameNum int
+	// FrameType identifies which of the 7 CEL decoding algorithms (0..6)
+	// decodes FrameNum.
+	FrameType int
+}
+
+// Parse parses the MIN file at name within fsys and returns its tile
+// pieces.
+func Parse(fsys assetfs.FS, name string) ([]*Piece, error) {
+	r, err := fsys.Open(name)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	return parsePieces(data, blocksPerPiece(name))
+}
+
+// blocksPerPiece returns the number of blocks per tile piece of the MIN file
+// identified by name; "town.min" uses 10-block pieces, every other level
+// tileset uses 16-block pieces.
+func blocksPerPiece(name string) int {
+	if strings.Contains(name, "town") {
+		return blocksPerPieceTown
+	}
+	return blocksPerPieceDungeon
+}
+
+// blockSize is the encoded size in bytes of a single block; each block is a
+// little-endian uint16 with the frame number in the low 12 bits and the
+// frame type in the high 4 bits.
+const blockSize = 2
+
+// parsePieces decodes data as a sequence of fixed-size tile pieces of
+// blocksPerPiece blocks each.
+func parsePieces(data []byte, blocksPerPiece int) ([]*Piece, error) {
+	pieceSize := blocksPerPiece * blockSize
+	if pieceSize == 0 || len(data)%pieceSize != 0 {
+		return nil, errutil.Newf("min: invalid MIN file length %d for %d blocks per piece", len(data), blocksPerPiece)
+	}
+	pieces := make([]*Piece, len(data)/pieceSize)
+	for i := range pieces {
+		raw := data[i*pieceSize : (i+1)*pieceSize]
+		blocks := make([]Block, blocksPerPiece)
+		for j := range blocks {
+			v := binary.LittleEndian.Uint16(raw[j*blockSize:])
+			blocks[j] = Block{
+				FrameNum:  int(v & 0x0FFF),
+				FrameType: int(v >> 12),
+			}
+		}
+		pieces[i] = &Piece{Blocks: blocks}
+	}
+	return pieces, nil
+}
@@ -0,0 +1,75 @@
+package min
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+// fakeFS is a minimal assetfs.FS backed by an in-memory file, just enough to
+// exercise Parse without a real asset source.
+type fakeFS struct {
+	data []byte
+}
+
+func (f fakeFS) Open(name string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+func (f fakeFS) Stat(name string) (fs.FileInfo, error) {
+	panic("not implemented")
+}
+
+func encodeBlock(frameNum, frameType int) uint16 {
+	return uint16(frameNum&0x0FFF) | uint16(frameType&0xF)<<12
+}
+
+func TestParseDungeonTileset(t *testing.T) {
+	// One 16-block piece, with a single non-empty block.
+	blocks := make([]uint16, blocksPerPieceDungeon)
+	blocks[3] = encodeBlock(42, 5)
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, blocks); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	pieces, err := Parse(fakeFS{data: buf.Bytes()}, "levels/l1data/l1.min")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(pieces) != 1 {
+		t.Fatalf("len(pieces) = %d, want 1", len(pieces))
+	}
+	if len(pieces[0].Blocks) != blocksPerPieceDungeon {
+		t.Fatalf("len(piece.Blocks) = %d, want %d", len(pieces[0].Blocks), blocksPerPieceDungeon)
+	}
+	got := pieces[0].Blocks[3]
+	if got.FrameNum != 42 || got.FrameType != 5 {
+		t.Errorf("Blocks[3] = %+v, want {FrameNum:42 FrameType:5}", got)
+	}
+}
+
+func TestParseTownUsesTenBlockPieces(t *testing.T) {
+	blocks := make([]uint16, blocksPerPieceTown)
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, blocks); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+
+	pieces, err := Parse(fakeFS{data: buf.Bytes()}, "levels/towndata/town.min")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(pieces) != 1 || len(pieces[0].Blocks) != blocksPerPieceTown {
+		t.Fatalf("got %d pieces of %d blocks, want 1 piece of %d blocks", len(pieces), len(pieces[0].Blocks), blocksPerPieceTown)
+	}
+}
+
+func TestParseRejectsMisalignedData(t *testing.T) {
+	_, err := Parse(fakeFS{data: []byte{0x01, 0x02, 0x03}}, "levels/l1data/l1.min")
+	if err == nil {
+		t.Fatal("Parse: expected error for misaligned data, got nil")
+	}
+}
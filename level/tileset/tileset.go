@@ -0,0 +1,106 @@
+// Package tileset discovers level tilesets (MIN files) from an asset source
+// at run-time, so that new levels may be plugged in -- by mods, or by
+// Hellfire's additional "l5", "l6", "nest" and "crypt" tilesets -- without
+// editing gen.go.
+package tileset
+
+import (
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mewkiz/pkg/errutil"
+	"github.com/sanctuary/formats/assetfs"
+	"github.com/sanctuary/formats/level/min"
+)
+
+// A LevelSet maps from level name (e.g. "l1", "town") to the frame-type
+// mapping of that level's MIN file.
+type LevelSet struct {
+	// frameTypes maps from level name to a mapping from frame number to
+	// frame type.
+	frameTypes map[string][]int
+}
+
+// Discover walks fsys for "*.min" files and parses each of them
+// concurrently, building a LevelSet keyed by level name (a MIN file's base
+// name, without extension).
+func Discover(fsys assetfs.FS) (*LevelSet, error) {
+	lister, ok := fsys.(assetfs.ListFS)
+	if !ok {
+		return nil, errutil.Newf("tileset: asset source does not support listing files")
+	}
+	names, err := lister.List()
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	var minPaths []string
+	for _, name := range names {
+		if strings.EqualFold(path.Ext(name), ".min") {
+			minPaths = append(minPaths, name)
+		}
+	}
+
+	type result struct {
+		levelName  string
+		frameTypes []int
+		err        error
+	}
+	results := make(chan result, len(minPaths))
+	var wg sync.WaitGroup
+	for _, minPath := range minPaths {
+		wg.Add(1)
+		go func(minPath string) {
+			defer wg.Done()
+			levelName := strings.TrimSuffix(path.Base(minPath), path.Ext(minPath))
+			frameTypes, err := parseFrameTypes(fsys, minPath)
+			results <- result{levelName: levelName, frameTypes: frameTypes, err: err}
+		}(minPath)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	ls := &LevelSet{frameTypes: make(map[string][]int, len(minPaths))}
+	for r := range results {
+		if r.err != nil {
+			return nil, errutil.Err(r.err)
+		}
+		ls.frameTypes[r.levelName] = r.frameTypes
+	}
+	return ls, nil
+}
+
+// FrameTypes returns the frame-type mapping of the named level, or nil if
+// the level was not discovered.
+func (ls *LevelSet) FrameTypes(levelName string) []int {
+	return ls.frameTypes[levelName]
+}
+
+// All returns the frame-type mappings of every discovered level, keyed by
+// level name.
+func (ls *LevelSet) All() map[string][]int {
+	return ls.frameTypes
+}
+
+// parseFrameTypes parses the MIN file at minPath and returns a mapping from
+// frame numbers to frame types.
+func parseFrameTypes(fsys assetfs.FS, minPath string) ([]int, error) {
+	pieces, err := min.Parse(fsys, minPath)
+	if err != nil {
+		return nil, errutil.Err(err)
+	}
+	// m maps from frame numbers to frame types.
+	m := make(map[int]int)
+	for _, piece := range pieces {
+		for _, block := range piece.Blocks {
+			m[block.FrameNum] = block.FrameType
+		}
+	}
+	frameTypes := make([]int, len(m))
+	for frameNum, frameType := range m {
+		frameTypes[frameNum] = frameType
+	}
+	return frameTypes, nil
+}